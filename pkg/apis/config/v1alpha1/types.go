@@ -6,7 +6,9 @@ package v1alpha1
 
 import (
 	healthcheckconfigv1alpha1 "github.com/gardener/gardener/extensions/pkg/apis/config/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
@@ -21,14 +23,243 @@ type Configuration struct {
 	HealthCheckConfig *healthcheckconfigv1alpha1.HealthCheckConfig `json:"healthCheckConfig,omitempty"`
 	// CosignPublicKeys is the cosign public keys used to verify image signatures.
 	CosignPublicKeys []string `json:"cosignPublicKeys,omitempty"`
+	// KeylessVerification configures keyless cosign signature verification via Fulcio-issued
+	// short-lived certificates, optionally backed by the Rekor transparency log. It can be used
+	// in addition to, or instead of, CosignPublicKeys.
+	// +optional
+	KeylessVerification *KeylessVerification `json:"keylessVerification,omitempty"`
 	// FailurePolicy is the failure policy used to configure the failurePolicy of the lakom admission webhooks.
 	// +optional
 	FailurePolicy *string `json:"failurePolicy,omitempty"`
+	// AttestationVerification configures verification of in-toto attestations (e.g. SLSA
+	// provenance) attached to images, in addition to plain signature verification.
+	// +optional
+	AttestationVerification *AttestationVerification `json:"attestationVerification,omitempty"`
+	// Monitoring configures how lakom metrics are wired up for scraping in the seed.
+	// +optional
+	Monitoring *MonitoringConfig `json:"monitoring,omitempty"`
+	// Disruption configures the PodDisruptionBudget and topology spread behaviour of the lakom
+	// deployment in the seed.
+	// +optional
+	Disruption *DisruptionConfig `json:"disruption,omitempty"`
+	// VerificationCache configures caching of signature and attestation verification results so
+	// that repeated admissions of the same image digest do not re-fetch verification material from
+	// the registry on every pod creation.
+	// +optional
+	VerificationCache *VerificationCache `json:"verificationCache,omitempty"`
+	// RegistryConfig configures per-registry authentication, TLS trust and mirror rewrites applied
+	// when resolving image references and fetching signatures/attestations.
+	// +optional
+	RegistryConfig *RegistryConfig `json:"registryConfig,omitempty"`
+	// Mode controls whether a verification failure blocks the pod (Enforce), admits it with an
+	// admission warning (Warn), or admits it silently while recording a violation (Audit). Defaults
+	// to Enforce. This lets operators roll Lakom onto existing shoots and observe what would break
+	// before tightening FailurePolicy to Fail.
+	// +optional
+	Mode *LakomMode `json:"mode,omitempty"`
+	// AuditSink configures where violation records are emitted when Mode is Warn or Audit. It has
+	// no effect when Mode is Enforce or unset.
+	// +optional
+	AuditSink *AuditSink `json:"auditSink,omitempty"`
 	// DebugConfig contains debug configurations for the controller.
 	// +optional
 	DebugConfig *DebugConfig `json:"debugConfig,omitempty"`
 }
 
+// LakomMode controls how the lakom admission webhooks react to a verification failure.
+type LakomMode string
+
+const (
+	// LakomModeEnforce blocks admission of pods that fail verification. This is the default.
+	LakomModeEnforce LakomMode = "Enforce"
+	// LakomModeWarn admits pods that fail verification but returns an admission warning listing
+	// the failing policy.
+	LakomModeWarn LakomMode = "Warn"
+	// LakomModeAudit admits pods that fail verification without an admission warning, and instead
+	// records the violation to the configured AuditSink.
+	LakomModeAudit LakomMode = "Audit"
+)
+
+// AuditSink configures where structured violation records (image, digest, policy, reason,
+// namespace, pod owner reference chain) are emitted in Warn or Audit mode.
+type AuditSink struct {
+	// Events, if true, emits a Kubernetes Event on the admitted pod recording the violation.
+	// +optional
+	Events bool `json:"events,omitempty"`
+	// WebhookURL, if set, is a URL the lakom binary POSTs a JSON violation record to.
+	// +optional
+	WebhookURL *string `json:"webhookURL,omitempty"`
+	// Log, if true, logs the violation record to the lakom binary's structured log stream.
+	// +optional
+	Log bool `json:"log,omitempty"`
+}
+
+// DisruptionConfig configures the PodDisruptionBudget and topology spread behaviour of the lakom
+// deployment in the seed.
+type DisruptionConfig struct {
+	// MinAvailable is the minimum number or percentage of available replicas required by the
+	// PodDisruptionBudget. Mutually exclusive with MaxUnavailable; if neither is set, the
+	// PodDisruptionBudget defaults to maxUnavailable: 1.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the maximum number or percentage of unavailable replicas tolerated by the
+	// PodDisruptionBudget. Mutually exclusive with MinAvailable.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// TopologySpreadConstraints, if set, are added to the lakom deployment's pod template in
+	// addition to its default same-hostname anti-affinity, e.g. to spread replicas across zones.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// VerificationCache configures caching of cosign signature and attestation verification results,
+// keyed by image digest and the hash of the verification policy applied to it, so that a policy
+// change automatically invalidates stale cache entries.
+type VerificationCache struct {
+	// MaxEntries is the maximum number of verification results held in the cache. If unset, the
+	// lakom binary's built-in default is used.
+	// +optional
+	MaxEntries *int32 `json:"maxEntries,omitempty"`
+	// PositiveTTL is how long a successful verification result is cached. If unset, a TTL of 10m
+	// is passed to the lakom binary.
+	// +optional
+	PositiveTTL *metav1.Duration `json:"positiveTTL,omitempty"`
+	// NegativeTTL is how long a failed verification result is cached. It should typically be
+	// shorter than PositiveTTL so that a freshly signed image is not rejected for longer than
+	// necessary. If unset, the lakom binary's built-in default is used.
+	// +optional
+	NegativeTTL *metav1.Duration `json:"negativeTTL,omitempty"`
+	// RedisURL, if set, configures a Redis instance as the cache backend instead of the lakom
+	// binary's in-memory LRU cache, so that the cache is shared across replicas.
+	// +optional
+	RedisURL *string `json:"redisURL,omitempty"`
+}
+
+// RegistryConfig configures image registry access used when resolving image references and
+// fetching signatures/attestations: authentication, TLS trust, and mirror rewrites.
+type RegistryConfig struct {
+	// Registries is the list of per-registry overrides. The first entry whose Host matches an
+	// image's registry host is applied.
+	// +optional
+	Registries []RegistryOverride `json:"registries,omitempty"`
+}
+
+// RegistryOverride configures access to a single image registry host.
+type RegistryOverride struct {
+	// Host is the registry host this override applies to, e.g. "docker.io" or "eu.gcr.io".
+	Host string `json:"host"`
+	// Mirror, if set, rewrites image references for this host to the given host before resolving
+	// and verifying them, e.g. "internal-mirror.example.com/dockerhub", so that signatures stored
+	// alongside a mirrored image are found without users having to re-sign into the mirror.
+	// +optional
+	Mirror string `json:"mirror,omitempty"`
+	// CredentialsSecretRef, if set, references a Secret in the same namespace as the lakom
+	// deployment holding dockerconfigjson credentials used to authenticate against this registry.
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+	// CABundleSecretRef, if set, references a Secret in the same namespace whose data holds a
+	// PEM-encoded CA bundle used to verify this registry's TLS certificate, e.g. for registries
+	// behind an internal CA in an airgapped landscape.
+	// +optional
+	CABundleSecretRef *corev1.LocalObjectReference `json:"caBundleSecretRef,omitempty"`
+	// InsecureSkipVerify disables TLS certificate verification for this registry. Only use this
+	// for airgapped test setups; it must never be set for a production registry.
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// MonitoringConfig configures whether a Prometheus Operator ServiceMonitor is rendered in
+// addition to, or instead of, the legacy scrape_config ConfigMap consumed by the seed's
+// Prometheus via config-map discovery.
+type MonitoringConfig struct {
+	// ServiceMonitor enables rendering a monitoring.coreos.com/v1 ServiceMonitor selecting the
+	// lakom service's metrics port.
+	// +optional
+	ServiceMonitor bool `json:"serviceMonitor,omitempty"`
+	// DisableScrapeConfigConfigMap disables rendering of the legacy scrape_config ConfigMap. It
+	// only has an effect when ServiceMonitor is true; setting it without enabling ServiceMonitor
+	// would leave the lakom service unmonitored.
+	// +optional
+	DisableScrapeConfigConfigMap bool `json:"disableScrapeConfigConfigMap,omitempty"`
+}
+
+// KeylessVerification contains the configuration for keyless cosign image signature
+// verification.
+type KeylessVerification struct {
+	// Identities is the list of identities an image signature is checked against. A signature is
+	// accepted if it matches at least one identity.
+	Identities []Identity `json:"identities,omitempty"`
+	// FulcioRootCertificateData is the PEM-encoded Fulcio root and intermediate certificate
+	// bundle used to verify the certificate chain of keyless signatures. If empty, the public
+	// Sigstore Fulcio root is used.
+	// +optional
+	FulcioRootCertificateData []byte `json:"fulcioRootCertificateData,omitempty"`
+	// RekorURL is the URL of the Rekor transparency log instance used to fetch and verify
+	// signature inclusion proofs. If empty, the public Sigstore Rekor instance is used.
+	// +optional
+	RekorURL *string `json:"rekorURL,omitempty"`
+	// RekorPublicKeyData is the PEM-encoded Rekor public key used to verify inclusion proof
+	// signatures. If empty, the public Sigstore Rekor key is used.
+	// +optional
+	RekorPublicKeyData []byte `json:"rekorPublicKeyData,omitempty"`
+	// RequireTLog requires a verified Rekor transparency log inclusion proof for a keyless
+	// signature to be accepted. Defaults to true; only disable this for Fulcio setups that do not
+	// publish to a transparency log.
+	// +optional
+	RequireTLog *bool `json:"requireTLog,omitempty"`
+}
+
+// Identity describes an expected keyless signer identity.
+type Identity struct {
+	// IssuerRegExp is a regular expression matched against the OIDC issuer claim of the signing
+	// certificate, e.g. "https://token.actions.githubusercontent.com".
+	IssuerRegExp string `json:"issuerRegExp"`
+	// SubjectRegExp is a regular expression matched against the signing certificate's subject
+	// (SAN), e.g. the GitHub Actions workflow identity "https://github.com/my-org/my-repo/.*".
+	SubjectRegExp string `json:"subjectRegExp"`
+}
+
+// AttestationVerification contains the configuration for verifying in-toto attestations attached
+// to an image, e.g. SLSA provenance statements.
+type AttestationVerification struct {
+	// Policies is the list of attestation policies an image must satisfy. An image is only
+	// admitted once every policy has a matching, successfully verified attestation.
+	Policies []AttestationPolicy `json:"policies,omitempty"`
+}
+
+// AttestationPolicy describes a single required in-toto attestation and constraints on its
+// predicate.
+type AttestationPolicy struct {
+	// PredicateType is the in-toto predicate type the attestation must have, e.g.
+	// "https://slsa.dev/provenance/v1".
+	PredicateType string `json:"predicateType"`
+	// RequiredBuilderIDRegExp, if set, is matched against the provenance predicate's builder ID.
+	// +optional
+	RequiredBuilderIDRegExp string `json:"requiredBuilderIDRegExp,omitempty"`
+	// SourceURIRegExp, if set, is matched against the provenance predicate's materials/source URI.
+	// +optional
+	SourceURIRegExp string `json:"sourceURIRegExp,omitempty"`
+	// MinSLSALevel, if set, is the minimum SLSA level the provenance predicate must declare.
+	// +optional
+	MinSLSALevel *int32 `json:"minSLSALevel,omitempty"`
+	// SignerPublicKeySecretRef, if set, references a Secret in the seed whose data holds the
+	// PEM-encoded cosign public key the attestation's DSSE envelope signature is checked against.
+	// If neither SignerPublicKeySecretRef nor SignerKeylessVerification is set, the attestation is
+	// checked against the image's own CosignPublicKeys/KeylessVerification.
+	// +optional
+	SignerPublicKeySecretRef *corev1.LocalObjectReference `json:"signerPublicKeySecretRef,omitempty"`
+	// SignerKeylessVerification, if set, additionally or instead admits attestations signed
+	// keylessly by one of the given identities.
+	// +optional
+	SignerKeylessVerification *KeylessVerification `json:"signerKeylessVerification,omitempty"`
+	// PolicyExpression, if set, is a CEL expression evaluated against the attestation's predicate
+	// payload (exposed to the expression as `predicate`); the attestation is only accepted if the
+	// expression evaluates to true, e.g. `predicate.builder.id.matches('^https://github.com/myorg/')`
+	// or `!predicate.components.exists(c, c.name == "log4j-core")`.
+	// +optional
+	PolicyExpression string `json:"policyExpression,omitempty"`
+}
+
 // DebugConfig contains debug configurations for the controller.
 type DebugConfig struct {
 	// EnableProfiling enables profiling via web interface host:port/debug/pprof/.
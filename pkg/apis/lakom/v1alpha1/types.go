@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	configv1alpha1 "github.com/gardener/gardener-extension-shoot-lakom-service/pkg/apis/config/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LakomPolicy declares the image verification rules enforced for the images it matches. Policies
+// are namespaced: a LakomPolicy only applies to pods in namespaces selected by its
+// NamespaceSelector (or, by default, only the namespace the policy itself lives in).
+//
+// Whether this CRD or a structured Policies/Exemptions list on Configuration should be this
+// extension's per-namespace/per-image policy surface is an open design question; it is the
+// backlog owner's call to make, not something this package should settle on its own. LakomPolicy
+// is the surface implemented so far.
+type LakomPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the desired verification behaviour for images matched by this policy.
+	Spec LakomPolicySpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LakomPolicyList is a list of LakomPolicy resources.
+type LakomPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []LakomPolicy `json:"items"`
+}
+
+// LakomPolicySpec contains the image selectors and verification requirements of a LakomPolicy.
+type LakomPolicySpec struct {
+	// ImageReferences is the list of patterns used to select the images this policy applies to.
+	// Each pattern is either a glob, e.g. "eu.gcr.io/my-project/*", or, prefixed with "regex:", a
+	// regular expression, e.g. "regex:^eu\\.gcr\\.io/my-project/.*$". An image is matched by this
+	// policy if it matches at least one pattern.
+	ImageReferences []string `json:"imageReferences"`
+
+	// Action determines how images matched by this policy are treated. Verify (the default)
+	// requires the image to satisfy CosignPublicKeySecretRef/KeylessVerification/
+	// RequiredPredicateTypes. Allow admits matched images unconditionally, which is how
+	// well-known infrastructure images (e.g. kube-system images) are exempted from verification.
+	// Deny unconditionally rejects matched images.
+	// +optional
+	// +kubebuilder:validation:Enum=Verify;Allow;Deny
+	Action LakomPolicyAction `json:"action,omitempty"`
+
+	// CosignPublicKeySecretRef, if set, references a Secret in the same namespace whose data
+	// holds the PEM-encoded cosign public keys an image signature is checked against. Has no
+	// effect when Action is Allow or Deny.
+	// +optional
+	CosignPublicKeySecretRef *corev1.LocalObjectReference `json:"cosignPublicKeySecretRef,omitempty"`
+
+	// KeylessVerification, if set, additionally or instead admits images signed keylessly by one
+	// of the given identities.
+	// +optional
+	KeylessVerification *configv1alpha1.KeylessVerification `json:"keylessVerification,omitempty"`
+
+	// RequiredPredicateTypes, if non-empty, is the list of in-toto predicate types an attestation
+	// attached to the image must satisfy, in addition to signature verification.
+	// +optional
+	RequiredPredicateTypes []string `json:"requiredPredicateTypes,omitempty"`
+
+	// NamespaceSelector restricts which namespaces this policy applies to. If nil, the policy
+	// only applies to the namespace it is defined in.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ExemptNamespaceSelector excludes matching namespaces from this policy, taking precedence
+	// over NamespaceSelector.
+	// +optional
+	ExemptNamespaceSelector *metav1.LabelSelector `json:"exemptNamespaceSelector,omitempty"`
+
+	// PodSelector restricts which pods this policy applies to based on pod labels. If nil, all
+	// pods in the selected namespaces are matched.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+}
+
+// LakomPolicyAction determines how images matched by a LakomPolicy are treated.
+type LakomPolicyAction string
+
+const (
+	// LakomPolicyActionVerify requires matched images to satisfy the policy's verification
+	// requirements. It is the default when Action is unset.
+	LakomPolicyActionVerify LakomPolicyAction = "Verify"
+	// LakomPolicyActionAllow unconditionally admits matched images without verification.
+	LakomPolicyActionAllow LakomPolicyAction = "Allow"
+	// LakomPolicyActionDeny unconditionally rejects matched images.
+	LakomPolicyActionDeny LakomPolicyAction = "Deny"
+)
@@ -0,0 +1,1005 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lifecycle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/gardener/gardener/pkg/client/kubernetes"
+	"github.com/gardener/gardener/pkg/resourcemanager/controller/garbagecollector/references"
+	"github.com/gardener/gardener/pkg/utils/managedresources"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	vpaautoscalingv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	lakomv1alpha1 "github.com/gardener/gardener-extension-shoot-lakom-service/pkg/apis/config/v1alpha1"
+	lakompolicyv1alpha1 "github.com/gardener/gardener-extension-shoot-lakom-service/pkg/apis/lakom/v1alpha1"
+)
+
+const (
+	extensionName              = "shoot-lakom-service"
+	serviceName                = "extension-" + extensionName
+	cosignPublicKeysSecretName = serviceName + "-cosign-public-keys"
+	resourceReaderName         = "gardener-extension-" + extensionName + "-resource-reader"
+	webhookName                = "gardener-extension-" + extensionName + "-shoot"
+
+	lakomPolicyAPIGroup = "lakom.extensions.gardener.cloud"
+	lakomPolicyResource = "lakompolicies"
+
+	cosignPublicKeysVolumeMountPath = "/etc/lakom/cosign"
+	cosignPublicKeyFileName         = "cosign.pub"
+	keylessPolicyFileName           = "keyless-policy.yaml"
+	fulcioRootCertFileName          = "fulcio-root.pem"
+	rekorPublicKeyFileName          = "rekor.pub"
+	attestationPolicyFileName       = "attestation-policy.yaml"
+	lakomPolicyFileName             = "lakom-policies.yaml"
+	registryConfigFileName          = "registry-config.yaml"
+)
+
+// getLabels returns the labels shared by all resources of the lakom extension.
+func getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":    "lakom",
+		"app.kubernetes.io/part-of": "shoot-lakom-service",
+	}
+}
+
+// getLakomReplicas returns the desired number of replicas for the lakom deployment depending on
+// whether the shoot is hibernated.
+func getLakomReplicas(hibernated bool) *int32 {
+	if hibernated {
+		return pointer.Int32(0)
+	}
+	return pointer.Int32(3)
+}
+
+// getPDB returns the policy/v1 PodDisruptionBudget for the lakom deployment. MinAvailable takes
+// precedence over MaxUnavailable if both are set on the DisruptionConfig; if neither is set, the
+// PodDisruptionBudget defaults to maxUnavailable: 1.
+func getPDB(replicas *int32, namespace string, disruption *lakomv1alpha1.DisruptionConfig) *policyv1.PodDisruptionBudget {
+	spec := policyv1.PodDisruptionBudgetSpec{
+		Selector: &metav1.LabelSelector{MatchLabels: getLabels()},
+	}
+
+	switch {
+	case disruption != nil && disruption.MinAvailable != nil:
+		spec.MinAvailable = disruption.MinAvailable
+	case disruption != nil && disruption.MaxUnavailable != nil:
+		spec.MaxUnavailable = disruption.MaxUnavailable
+	default:
+		maxUnavailable := intstr.FromInt(1)
+		spec.MaxUnavailable = &maxUnavailable
+	}
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+			Labels:    getLabels(),
+		},
+		Spec: spec,
+	}
+}
+
+// getShootResources returns the resources that need to be deployed into the shoot cluster's
+// kube-system namespace: the webhook configurations routing pod admission through the seed-side
+// lakom deployment and the RBAC objects granting the shoot-access service account read access to
+// the secrets and LakomPolicy objects referenced by the webhooks.
+func getShootResources(caBundle []byte, namespace, shootAccessServiceAccountName string, failurePolicy admissionregistrationv1.FailurePolicyType, attestationVerification *lakomv1alpha1.AttestationVerification, policies []lakompolicyv1alpha1.LakomPolicy) (map[string][]byte, error) {
+	var (
+		namespaceSelector = aggregateNamespaceSelector(policies)
+		objectSelector    = aggregateObjectSelector(policies)
+		rules             = []admissionregistrationv1.RuleWithOperations{{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{""},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"pods", "pods/ephemeralcontainers"},
+			},
+		}}
+		sideEffects     = admissionregistrationv1.SideEffectClassNone
+		timeoutSeconds  = pointer.Int32(25)
+		admissionReview = []string{"v1"}
+	)
+
+	mutatingWebhookConfig := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: webhookName,
+			Labels: mergeLabels(getLabels(), map[string]string{
+				"remediation.webhook.shoot.gardener.cloud/exclude": "true",
+			}),
+		},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{{
+			Name:                    "resolve-tag.lakom.service.extensions.gardener.cloud",
+			AdmissionReviewVersions: admissionReview,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				CABundle: caBundle,
+				URL:      pointer.String(fmt.Sprintf("https://%s.%s/lakom/resolve-tag-to-digest", serviceName, namespace)),
+			},
+			FailurePolicy:     &failurePolicy,
+			MatchPolicy:       matchPolicy(),
+			NamespaceSelector: namespaceSelector,
+			ObjectSelector:    objectSelector,
+			Rules:             rules,
+			SideEffects:       &sideEffects,
+			TimeoutSeconds:    timeoutSeconds,
+		}},
+	}
+
+	validatingWebhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: webhookName,
+			Labels: mergeLabels(getLabels(), map[string]string{
+				"remediation.webhook.shoot.gardener.cloud/exclude": "true",
+			}),
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name:                    "verify-signature.lakom.service.extensions.gardener.cloud",
+			AdmissionReviewVersions: admissionReview,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				CABundle: caBundle,
+				URL:      pointer.String(fmt.Sprintf("https://%s.%s/lakom/verify-cosign-signature", serviceName, namespace)),
+			},
+			FailurePolicy:     &failurePolicy,
+			MatchPolicy:       matchPolicy(),
+			NamespaceSelector: namespaceSelector,
+			ObjectSelector:    objectSelector,
+			Rules:             rules,
+			SideEffects:       &sideEffects,
+			TimeoutSeconds:    timeoutSeconds,
+		}},
+	}
+
+	if attestationVerification != nil && len(attestationVerification.Policies) > 0 {
+		validatingWebhookConfig.Webhooks = append(validatingWebhookConfig.Webhooks, admissionregistrationv1.ValidatingWebhook{
+			Name:                    "verify-attestation.lakom.service.extensions.gardener.cloud",
+			AdmissionReviewVersions: admissionReview,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				CABundle: caBundle,
+				URL:      pointer.String(fmt.Sprintf("https://%s.%s/lakom/verify-attestation", serviceName, namespace)),
+			},
+			FailurePolicy:     &failurePolicy,
+			MatchPolicy:       matchPolicy(),
+			NamespaceSelector: namespaceSelector,
+			ObjectSelector:    objectSelector,
+			Rules:             rules,
+			SideEffects:       &sideEffects,
+			TimeoutSeconds:    timeoutSeconds,
+		})
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceReaderName,
+			Namespace: metav1.NamespaceSystem,
+			Labels:    getLabels(),
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"get"},
+			},
+			{
+				APIGroups: []string{lakomPolicyAPIGroup},
+				Resources: []string{lakomPolicyResource},
+				Verbs:     []string{"list", "watch"},
+			},
+		},
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceReaderName,
+			Namespace: metav1.NamespaceSystem,
+			Labels:    getLabels(),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     resourceReaderName,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      shootAccessServiceAccountName,
+			Namespace: metav1.NamespaceSystem,
+		}},
+	}
+
+	registry := managedresources.NewRegistry(kubernetes.ShootScheme, kubernetes.ShootCodec, kubernetes.ShootSerializer)
+	return registry.AddAllAndSerialize(mutatingWebhookConfig, validatingWebhookConfig, role, roleBinding)
+}
+
+func matchPolicy() *admissionregistrationv1.MatchPolicyType {
+	p := admissionregistrationv1.Equivalent
+	return &p
+}
+
+// defaultNamespaceSelector is the namespaceSelector applied when no LakomPolicy is configured,
+// preserving the pre-LakomPolicy behaviour of only matching the kube-system namespace.
+func defaultNamespaceSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "kubernetes.io/metadata.name",
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{metav1.NamespaceSystem},
+		}},
+	}
+}
+
+// defaultObjectSelector is the objectSelector applied when no LakomPolicy is configured,
+// preserving the pre-LakomPolicy behaviour of only matching Gardener-managed pods.
+func defaultObjectSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "resources.gardener.cloud/managed-by",
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{"gardener"},
+		}},
+	}
+}
+
+// aggregateNamespaceSelector returns a namespaceSelector admitting the union of namespaces the
+// given policies apply to, so that the webhooks receive admission reviews for every namespace a
+// LakomPolicy might need to evaluate. Fine-grained enforcement of each policy's own
+// NamespaceSelector, ExemptNamespaceSelector and PodSelector happens inside the lakom webhook
+// server, which has access to the full LakomPolicy objects; this selector is only the coarse
+// filter deciding whether a request reaches the webhook at all. A NamespaceSelector that is not
+// made up solely of kubernetes.io/metadata.name In requirements cannot be turned into a list of
+// names, so the selector is then relaxed to nil (match all namespaces). When no policies are
+// configured, the default kube-system-only selector is kept for backwards compatibility.
+func aggregateNamespaceSelector(policies []lakompolicyv1alpha1.LakomPolicy) *metav1.LabelSelector {
+	if len(policies) == 0 {
+		return defaultNamespaceSelector()
+	}
+
+	names := sets.NewString(metav1.NamespaceSystem)
+	for _, policy := range policies {
+		if policy.Spec.NamespaceSelector == nil {
+			names.Insert(policy.Namespace)
+			continue
+		}
+		if len(policy.Spec.NamespaceSelector.MatchLabels) > 0 || len(policy.Spec.NamespaceSelector.MatchExpressions) == 0 {
+			return nil
+		}
+		for _, requirement := range policy.Spec.NamespaceSelector.MatchExpressions {
+			if requirement.Key != "kubernetes.io/metadata.name" || requirement.Operator != metav1.LabelSelectorOpIn {
+				return nil
+			}
+			names.Insert(requirement.Values...)
+		}
+	}
+
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "kubernetes.io/metadata.name",
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   names.List(),
+		}},
+	}
+}
+
+// aggregateObjectSelector returns the objectSelector admitting the union of pods the given
+// policies apply to. Unlike a namespaceSelector, matchExpressions cannot be used to OR two
+// differing PodSelectors together: every requirement in a LabelSelector is ANDed. The only case
+// this can soundly express as a single selector is when every configured policy restricts
+// PodSelector to equality matches (MatchLabels) on the very same key, in which case the allowed
+// values can be unioned into one matchExpressions In-list. In every other case (a policy with no
+// or an empty PodSelector, i.e. matching every pod in its namespaces; differing keys; or non-equality
+// matchExpressions) there is no narrower selector guaranteed to admit every pod the policies care
+// about, so the selector is relaxed to nil (match all pods) rather than silently dropping some of
+// them before they ever reach the webhook. When no policies are configured, the default
+// Gardener-managed-only selector is kept for backwards compatibility.
+func aggregateObjectSelector(policies []lakompolicyv1alpha1.LakomPolicy) *metav1.LabelSelector {
+	if len(policies) == 0 {
+		return defaultObjectSelector()
+	}
+
+	var key string
+	values := sets.NewString()
+	for _, policy := range policies {
+		if policy.Spec.PodSelector == nil || len(policy.Spec.PodSelector.MatchExpressions) > 0 || len(policy.Spec.PodSelector.MatchLabels) == 0 {
+			return nil
+		}
+		for k, v := range policy.Spec.PodSelector.MatchLabels {
+			if key != "" && k != key {
+				return nil
+			}
+			key = k
+			values.Insert(v)
+		}
+	}
+	if key == "" {
+		return nil
+	}
+
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      key,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   values.List(),
+		}},
+	}
+}
+
+func mergeLabels(labelSets ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, labels := range labelSets {
+		for k, v := range labels {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// cosignSecretName returns the name of the immutable Secret holding the cosign verification
+// material, suffixed with a hash of its content so that rotating the keys or the keyless
+// verification policy triggers a replacement of the Secret instead of an in-place update.
+func cosignSecretName(cosignPublicKeys []string, keylessVerification *lakomv1alpha1.KeylessVerification, attestationVerification *lakomv1alpha1.AttestationVerification, policies []lakompolicyv1alpha1.LakomPolicy, registryConfig *lakomv1alpha1.RegistryConfig) string {
+	hasher := sha256.New()
+	for _, key := range cosignPublicKeys {
+		hasher.Write([]byte(key))
+	}
+	hashKeylessVerification(hasher, keylessVerification)
+	if attestationVerification != nil {
+		for _, policy := range attestationVerification.Policies {
+			hasher.Write([]byte(policy.PredicateType))
+			hasher.Write([]byte(policy.RequiredBuilderIDRegExp))
+			hasher.Write([]byte(policy.SourceURIRegExp))
+			if policy.MinSLSALevel != nil {
+				hasher.Write([]byte(fmt.Sprintf("%d", *policy.MinSLSALevel)))
+			}
+			if policy.SignerPublicKeySecretRef != nil {
+				hasher.Write([]byte(policy.SignerPublicKeySecretRef.Name))
+			}
+			hashKeylessVerification(hasher, policy.SignerKeylessVerification)
+			hasher.Write([]byte(policy.PolicyExpression))
+		}
+	}
+	for _, policy := range policies {
+		hasher.Write([]byte(policy.Namespace))
+		hasher.Write([]byte(policy.Name))
+		hasher.Write([]byte(policy.Spec.Action))
+		for _, ref := range policy.Spec.ImageReferences {
+			hasher.Write([]byte(ref))
+		}
+		if policy.Spec.CosignPublicKeySecretRef != nil {
+			hasher.Write([]byte(policy.Spec.CosignPublicKeySecretRef.Name))
+		}
+		hashKeylessVerification(hasher, policy.Spec.KeylessVerification)
+		for _, predicateType := range policy.Spec.RequiredPredicateTypes {
+			hasher.Write([]byte(predicateType))
+		}
+	}
+	if registryConfig != nil {
+		for _, registry := range registryConfig.Registries {
+			hasher.Write([]byte(registry.Host))
+			hasher.Write([]byte(registry.Mirror))
+			if registry.CredentialsSecretRef != nil {
+				hasher.Write([]byte(registry.CredentialsSecretRef.Name))
+			}
+			if registry.CABundleSecretRef != nil {
+				hasher.Write([]byte(registry.CABundleSecretRef.Name))
+			}
+			hasher.Write([]byte(fmt.Sprintf("%t", registry.InsecureSkipVerify)))
+		}
+	}
+	return cosignPublicKeysSecretName + "-" + hex.EncodeToString(hasher.Sum(nil))[:8]
+}
+
+// hashKeylessVerification writes kv's fields into hasher, so that a content hash covering it
+// (e.g. cosignSecretName's) changes whenever the keyless verification material or identities it
+// describes change. A no-op if kv is nil.
+func hashKeylessVerification(hasher hash.Hash, kv *lakomv1alpha1.KeylessVerification) {
+	if kv == nil {
+		return
+	}
+	hasher.Write(kv.FulcioRootCertificateData)
+	hasher.Write(kv.RekorPublicKeyData)
+	if kv.RekorURL != nil {
+		hasher.Write([]byte(*kv.RekorURL))
+	}
+	if kv.RequireTLog != nil {
+		hasher.Write([]byte(fmt.Sprintf("%t", *kv.RequireTLog)))
+	}
+	for _, identity := range kv.Identities {
+		hasher.Write([]byte(identity.IssuerRegExp))
+		hasher.Write([]byte(identity.SubjectRegExp))
+	}
+}
+
+// keylessPolicyFile is the schema of the YAML file rendered to keylessPolicyFileName, consumed by
+// the lakom binary via --keyless-policy-path.
+type keylessPolicyFile struct {
+	Identities []lakomv1alpha1.Identity `json:"identities,omitempty"`
+}
+
+// renderKeylessPolicy renders the keyless verification configuration into the YAML policy file
+// consumed by the lakom binary via --keyless-policy-path.
+func renderKeylessPolicy(keylessVerification *lakomv1alpha1.KeylessVerification) (string, error) {
+	out, err := yaml.Marshal(keylessPolicyFile{Identities: keylessVerification.Identities})
+	if err != nil {
+		return "", fmt.Errorf("marshalling keyless policy file: %w", err)
+	}
+	return string(out), nil
+}
+
+// attestationPolicyFile is the schema of the YAML file rendered to attestationPolicyFileName,
+// consumed by the lakom binary's /lakom/verify-attestation endpoint.
+type attestationPolicyFile struct {
+	Policies []attestationPolicyFileEntry `json:"policies"`
+}
+
+type attestationPolicyFileEntry struct {
+	PredicateType             string                             `json:"predicateType"`
+	RequiredBuilderIDRegExp   string                             `json:"requiredBuilderIDRegExp,omitempty"`
+	SourceURIRegExp           string                             `json:"sourceURIRegExp,omitempty"`
+	MinSLSALevel              *int32                             `json:"minSLSALevel,omitempty"`
+	SignerPublicKeySecretRef  string                             `json:"signerPublicKeySecretRef,omitempty"`
+	SignerKeylessVerification *lakomv1alpha1.KeylessVerification `json:"signerKeylessVerification,omitempty"`
+	PolicyExpression          string                             `json:"policyExpression,omitempty"`
+}
+
+// renderAttestationPolicy renders the required attestation policies into the YAML policy file
+// consumed by the lakom binary's /lakom/verify-attestation endpoint.
+func renderAttestationPolicy(attestationVerification *lakomv1alpha1.AttestationVerification) (string, error) {
+	file := attestationPolicyFile{}
+	for _, policy := range attestationVerification.Policies {
+		entry := attestationPolicyFileEntry{
+			PredicateType:             policy.PredicateType,
+			RequiredBuilderIDRegExp:   policy.RequiredBuilderIDRegExp,
+			SourceURIRegExp:           policy.SourceURIRegExp,
+			MinSLSALevel:              policy.MinSLSALevel,
+			SignerKeylessVerification: policy.SignerKeylessVerification,
+			PolicyExpression:          policy.PolicyExpression,
+		}
+		if policy.SignerPublicKeySecretRef != nil {
+			entry.SignerPublicKeySecretRef = policy.SignerPublicKeySecretRef.Name
+		}
+		file.Policies = append(file.Policies, entry)
+	}
+
+	out, err := yaml.Marshal(file)
+	if err != nil {
+		return "", fmt.Errorf("marshalling attestation policy file: %w", err)
+	}
+	return string(out), nil
+}
+
+// lakomPoliciesFile is the schema of the YAML file rendered to lakomPolicyFileName, consumed by
+// the lakom binary. Fine-grained matching (per-policy NamespaceSelector, ExemptNamespaceSelector
+// and PodSelector) and verification material resolution are performed by the lakom binary itself
+// at admission time.
+type lakomPoliciesFile struct {
+	Policies []lakomPoliciesFileEntry `json:"policies"`
+}
+
+type lakomPoliciesFileEntry struct {
+	Name                     string                                `json:"name"`
+	Namespace                string                                `json:"namespace"`
+	ImageReferences          []string                              `json:"imageReferences"`
+	Action                   lakompolicyv1alpha1.LakomPolicyAction `json:"action,omitempty"`
+	CosignPublicKeySecretRef string                                `json:"cosignPublicKeySecretRef,omitempty"`
+	KeylessVerification      *lakomv1alpha1.KeylessVerification    `json:"keylessVerification,omitempty"`
+	RequiredPredicateTypes   []string                              `json:"requiredPredicateTypes,omitempty"`
+}
+
+// renderLakomPolicies renders the projected view of the LakomPolicy set into the YAML policy
+// file consumed by the lakom binary.
+func renderLakomPolicies(policies []lakompolicyv1alpha1.LakomPolicy) (string, error) {
+	file := lakomPoliciesFile{}
+	for _, policy := range policies {
+		entry := lakomPoliciesFileEntry{
+			Name:                   policy.Name,
+			Namespace:              policy.Namespace,
+			ImageReferences:        policy.Spec.ImageReferences,
+			Action:                 policy.Spec.Action,
+			KeylessVerification:    policy.Spec.KeylessVerification,
+			RequiredPredicateTypes: policy.Spec.RequiredPredicateTypes,
+		}
+		if policy.Spec.CosignPublicKeySecretRef != nil {
+			entry.CosignPublicKeySecretRef = policy.Spec.CosignPublicKeySecretRef.Name
+		}
+		file.Policies = append(file.Policies, entry)
+	}
+
+	out, err := yaml.Marshal(file)
+	if err != nil {
+		return "", fmt.Errorf("marshalling lakom policies file: %w", err)
+	}
+	return string(out), nil
+}
+
+// registryConfigFile is the schema of the YAML file rendered to registryConfigFileName, consumed
+// by the lakom binary via --registry-config-path.
+type registryConfigFile struct {
+	Registries []registryConfigFileEntry `json:"registries"`
+}
+
+type registryConfigFileEntry struct {
+	Host                 string `json:"host"`
+	Mirror               string `json:"mirror,omitempty"`
+	CredentialsSecretRef string `json:"credentialsSecretRef,omitempty"`
+	CABundleSecretRef    string `json:"caBundleSecretRef,omitempty"`
+	InsecureSkipVerify   bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// renderRegistryConfig renders the per-registry authentication, TLS trust and mirror rewrite
+// configuration into the YAML policy file consumed by the lakom binary via
+// --registry-config-path.
+func renderRegistryConfig(registryConfig *lakomv1alpha1.RegistryConfig) (string, error) {
+	file := registryConfigFile{}
+	for _, registry := range registryConfig.Registries {
+		entry := registryConfigFileEntry{
+			Host:               registry.Host,
+			Mirror:             registry.Mirror,
+			InsecureSkipVerify: registry.InsecureSkipVerify,
+		}
+		if registry.CredentialsSecretRef != nil {
+			entry.CredentialsSecretRef = registry.CredentialsSecretRef.Name
+		}
+		if registry.CABundleSecretRef != nil {
+			entry.CABundleSecretRef = registry.CABundleSecretRef.Name
+		}
+		file.Registries = append(file.Registries, entry)
+	}
+
+	out, err := yaml.Marshal(file)
+	if err != nil {
+		return "", fmt.Errorf("marshalling registry config file: %w", err)
+	}
+	return string(out), nil
+}
+
+// getSeedResources returns the resources that need to be deployed into the seed cluster's shoot
+// namespace to run the lakom admission webhook server for the given shoot.
+func getSeedResources(
+	replicas *int32,
+	namespace string,
+	genericKubeconfigName string,
+	shootAccessServiceAccountName string,
+	serverTLSSecretName string,
+	cosignPublicKeys []string,
+	keylessVerification *lakomv1alpha1.KeylessVerification,
+	attestationVerification *lakomv1alpha1.AttestationVerification,
+	policies []lakompolicyv1alpha1.LakomPolicy,
+	monitoring *lakomv1alpha1.MonitoringConfig,
+	disruption *lakomv1alpha1.DisruptionConfig,
+	verificationCache *lakomv1alpha1.VerificationCache,
+	registryConfig *lakomv1alpha1.RegistryConfig,
+	mode *lakomv1alpha1.LakomMode,
+	auditSink *lakomv1alpha1.AuditSink,
+	image string,
+) (map[string][]byte, error) {
+	secretCosignName := cosignSecretName(cosignPublicKeys, keylessVerification, attestationVerification, policies, registryConfig)
+
+	monitoringResources := getMonitoringResources(namespace, monitoring)
+
+	stringData := map[string]string{}
+	if len(cosignPublicKeys) > 0 {
+		stringData[cosignPublicKeyFileName] = strings.Join(cosignPublicKeys, "")
+	}
+	if keylessVerification != nil {
+		renderedKeylessPolicy, err := renderKeylessPolicy(keylessVerification)
+		if err != nil {
+			return nil, err
+		}
+		stringData[keylessPolicyFileName] = renderedKeylessPolicy
+		if len(keylessVerification.FulcioRootCertificateData) > 0 {
+			stringData[fulcioRootCertFileName] = string(keylessVerification.FulcioRootCertificateData)
+		}
+		if len(keylessVerification.RekorPublicKeyData) > 0 {
+			stringData[rekorPublicKeyFileName] = string(keylessVerification.RekorPublicKeyData)
+		}
+	}
+	if attestationVerification != nil && len(attestationVerification.Policies) > 0 {
+		renderedAttestationPolicy, err := renderAttestationPolicy(attestationVerification)
+		if err != nil {
+			return nil, err
+		}
+		stringData[attestationPolicyFileName] = renderedAttestationPolicy
+	}
+	if len(policies) > 0 {
+		renderedLakomPolicies, err := renderLakomPolicies(policies)
+		if err != nil {
+			return nil, err
+		}
+		stringData[lakomPolicyFileName] = renderedLakomPolicies
+	}
+	if registryConfig != nil && len(registryConfig.Registries) > 0 {
+		renderedRegistryConfig, err := renderRegistryConfig(registryConfig)
+		if err != nil {
+			return nil, err
+		}
+		stringData[registryConfigFileName] = renderedRegistryConfig
+	}
+
+	cosignSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretCosignName,
+			Namespace: namespace,
+			Labels: mergeLabels(getLabels(), map[string]string{
+				"resources.gardener.cloud/garbage-collectable-reference": "true",
+			}),
+		},
+		Immutable:  pointer.Bool(true),
+		Type:       corev1.SecretTypeOpaque,
+		StringData: stringData,
+	}
+
+	pdb := getPDB(replicas, namespace, disruption)
+
+	deployment := getDeployment(replicas, namespace, genericKubeconfigName, shootAccessServiceAccountName, image, secretCosignName, serverTLSSecretName, len(cosignPublicKeys) > 0, keylessVerification, attestationVerification, policies, disruption, verificationCache, registryConfig, mode, auditSink)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+			Labels:    getLabels(),
+			Annotations: map[string]string{
+				"networking.resources.gardener.cloud/from-policy-allowed-ports":      `[{"protocol":"TCP","port":8080}]`,
+				"networking.resources.gardener.cloud/from-policy-pod-label-selector": "all-scrape-targets",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: getLabels(),
+			Ports: []corev1.ServicePort{
+				{Name: "https", Port: 443, TargetPort: intstr.FromInt(10250), Protocol: corev1.ProtocolTCP},
+				{Name: "metrics", Port: 2718, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      shootAccessServiceAccountName,
+			Namespace: namespace,
+			Labels:    getLabels(),
+		},
+		AutomountServiceAccountToken: pointer.Bool(false),
+	}
+
+	vpa := getVPA(namespace)
+
+	objects := append([]client.Object{deployment, pdb, cosignSecret, service, serviceAccount, vpa}, monitoringResources...)
+
+	registry := managedresources.NewRegistry(kubernetes.SeedScheme, kubernetes.SeedCodec, kubernetes.SeedSerializer)
+	return registry.AddAllAndSerialize(objects...)
+}
+
+// getMonitoringResources returns the resources wiring lakom metrics up for scraping: the legacy
+// scrape_config ConfigMap, a Prometheus Operator ServiceMonitor, or both, depending on the given
+// MonitoringConfig.
+//
+// Neither flavour sets a BearerTokenSecret or TLSConfig: the metrics port is plain HTTP (see the
+// "metrics" Service/container port) and reachable only from the seed's Prometheus via the
+// networking.resources.gardener.cloud/from-policy-allowed-ports NetworkPolicy annotation on the
+// Service, matching the legacy scrape_config this replaces/augments. If the metrics endpoint is
+// ever served over TLS, both flavours need a matching TLSConfig/Scheme change here.
+func getMonitoringResources(namespace string, monitoring *lakomv1alpha1.MonitoringConfig) []client.Object {
+	serviceMonitorEnabled := monitoring != nil && monitoring.ServiceMonitor
+	configMapEnabled := !serviceMonitorEnabled || !monitoring.DisableScrapeConfigConfigMap
+
+	var resources []client.Object
+
+	if configMapEnabled {
+		resources = append(resources, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      serviceName + "-monitoring",
+				Namespace: namespace,
+				Labels: mergeLabels(getLabels(), map[string]string{
+					"extensions.gardener.cloud/configuration": "monitoring",
+				}),
+			},
+			Data: map[string]string{
+				"scrape_config": buildScrapeConfig(namespace),
+			},
+		})
+	}
+
+	if serviceMonitorEnabled {
+		resources = append(resources, &monitoringv1.ServiceMonitor{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      serviceName,
+				Namespace: namespace,
+				Labels: mergeLabels(getLabels(), map[string]string{
+					"extensions.gardener.cloud/configuration": "monitoring",
+				}),
+			},
+			Spec: monitoringv1.ServiceMonitorSpec{
+				Selector: metav1.LabelSelector{MatchLabels: getLabels()},
+				Endpoints: []monitoringv1.Endpoint{{
+					Port:   "metrics",
+					Scheme: "http",
+					MetricRelabelConfigs: []*monitoringv1.RelabelConfig{{
+						SourceLabels: []monitoringv1.LabelName{"__name__"},
+						Regex:        "^lakom.*$",
+						Action:       "keep",
+					}},
+				}},
+			},
+		})
+	}
+
+	return resources
+}
+
+func buildScrapeConfig(namespace string) string {
+	return `- job_name: extension-shoot-lakom-service
+  honor_labels: false
+  kubernetes_sd_configs:
+  - role: endpoints
+    namespaces:
+      names: [` + namespace + `]
+  relabel_configs:
+  - source_labels:
+    - __meta_kubernetes_service_name
+    - __meta_kubernetes_endpoint_port_name
+    action: keep
+    regex: extension-shoot-lakom-service;metrics
+  # common metrics
+  - action: drop
+    regex: __meta_kubernetes_service_label_(.+)
+  - source_labels: [ __meta_kubernetes_pod_name ]
+    target_label: pod
+  - source_labels: [ __meta_kubernetes_pod_container_name ]
+    target_label: container
+  metric_relabel_configs:
+  - source_labels: [ __name__ ]
+    regex: ^lakom.*$
+    action: keep
+`
+}
+
+func getDeployment(
+	replicas *int32,
+	namespace, genericKubeconfigSecretName, shootAccessSecretName, image, cosignPublicKeysSecretName, serverTLSSecretName string,
+	hasCosignPublicKeys bool,
+	keylessVerification *lakomv1alpha1.KeylessVerification,
+	attestationVerification *lakomv1alpha1.AttestationVerification,
+	policies []lakompolicyv1alpha1.LakomPolicy,
+	disruption *lakomv1alpha1.DisruptionConfig,
+	verificationCache *lakomv1alpha1.VerificationCache,
+	registryConfig *lakomv1alpha1.RegistryConfig,
+	mode *lakomv1alpha1.LakomMode,
+	auditSink *lakomv1alpha1.AuditSink,
+) *appsv1.Deployment {
+	cacheTTL := "10m0s"
+	if verificationCache != nil && verificationCache.PositiveTTL != nil {
+		cacheTTL = verificationCache.PositiveTTL.Duration.String()
+	}
+	args := []string{
+		"--cache-ttl=" + cacheTTL,
+		"--cache-refresh-interval=30s",
+	}
+	if verificationCache != nil {
+		if verificationCache.MaxEntries != nil {
+			args = append(args, fmt.Sprintf("--cache-max-entries=%d", *verificationCache.MaxEntries))
+		}
+		if verificationCache.NegativeTTL != nil {
+			args = append(args, "--cache-negative-ttl="+verificationCache.NegativeTTL.Duration.String())
+		}
+		if verificationCache.RedisURL != nil {
+			args = append(args, "--cache-redis-url="+*verificationCache.RedisURL)
+		}
+	}
+	if hasCosignPublicKeys {
+		args = append(args, "--cosign-public-key-path="+cosignPublicKeysVolumeMountPath+"/"+cosignPublicKeyFileName)
+	}
+	if keylessVerification != nil {
+		rekorURL := "https://rekor.sigstore.dev"
+		if keylessVerification.RekorURL != nil {
+			rekorURL = *keylessVerification.RekorURL
+		}
+		requireTLog := true
+		if keylessVerification.RequireTLog != nil {
+			requireTLog = *keylessVerification.RequireTLog
+		}
+		args = append(args,
+			"--keyless-policy-path="+cosignPublicKeysVolumeMountPath+"/"+keylessPolicyFileName,
+			"--rekor-url="+rekorURL,
+			fmt.Sprintf("--insecure-ignore-tlog=%t", !requireTLog),
+		)
+		if len(keylessVerification.FulcioRootCertificateData) > 0 {
+			args = append(args, "--fulcio-root-path="+cosignPublicKeysVolumeMountPath+"/"+fulcioRootCertFileName)
+		}
+		if len(keylessVerification.RekorPublicKeyData) > 0 {
+			args = append(args, "--rekor-public-key-path="+cosignPublicKeysVolumeMountPath+"/"+rekorPublicKeyFileName)
+		}
+	}
+	if attestationVerification != nil && len(attestationVerification.Policies) > 0 {
+		args = append(args, "--attestation-policy-path="+cosignPublicKeysVolumeMountPath+"/"+attestationPolicyFileName)
+	}
+	if len(policies) > 0 {
+		args = append(args, "--lakom-policy-path="+cosignPublicKeysVolumeMountPath+"/"+lakomPolicyFileName)
+	}
+	if registryConfig != nil && len(registryConfig.Registries) > 0 {
+		args = append(args, "--registry-config-path="+cosignPublicKeysVolumeMountPath+"/"+registryConfigFileName)
+	}
+	if mode != nil {
+		args = append(args, "--mode="+string(*mode))
+	}
+	if auditSink != nil {
+		if auditSink.Events {
+			args = append(args, "--audit-events=true")
+		}
+		if auditSink.WebhookURL != nil {
+			args = append(args, "--audit-webhook-url="+*auditSink.WebhookURL)
+		}
+		if auditSink.Log {
+			args = append(args, "--audit-log=true")
+		}
+	}
+	args = append(args,
+		"--tls-cert-dir=/etc/lakom/tls",
+		"--health-bind-address=:8081",
+		"--metrics-bind-address=:8080",
+		"--port=10250",
+		"--kubeconfig=/var/run/secrets/gardener.cloud/shoot/generic-kubeconfig/kubeconfig",
+	)
+
+	annotations := map[string]string{
+		references.AnnotationKey("secret", genericKubeconfigSecretName): genericKubeconfigSecretName,
+		references.AnnotationKey("secret", shootAccessSecretName):       shootAccessSecretName,
+		references.AnnotationKey("secret", serverTLSSecretName):         serverTLSSecretName,
+		references.AnnotationKey("secret", cosignPublicKeysSecretName):  cosignPublicKeysSecretName,
+	}
+
+	labels := getLabels()
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        serviceName,
+			Namespace:   namespace,
+			Labels:      mergeLabels(labels, map[string]string{"high-availability-config.resources.gardener.cloud/type": "server"}),
+			Annotations: annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas:             replicas,
+			RevisionHistoryLimit: pointer.Int32(2),
+			Selector:             &metav1.LabelSelector{MatchLabels: labels},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxSurge:       intOrStrPtr(intstr.FromInt(1)),
+					MaxUnavailable: intOrStrPtr(intstr.FromInt(0)),
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: annotations,
+					Labels: mergeLabels(labels, map[string]string{
+						"networking.gardener.cloud/to-dns":                              "allowed",
+						"networking.gardener.cloud/to-private-networks":                 "allowed",
+						"networking.gardener.cloud/to-public-networks":                  "allowed",
+						"networking.resources.gardener.cloud/to-kube-apiserver-tcp-443": "allowed",
+					}),
+				},
+				Spec: corev1.PodSpec{
+					AutomountServiceAccountToken: pointer.Bool(false),
+					PriorityClassName:            "gardener-system-300",
+					ServiceAccountName:           serviceName,
+					Affinity: &corev1.Affinity{
+						PodAntiAffinity: &corev1.PodAntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+								Weight: 100,
+								PodAffinityTerm: corev1.PodAffinityTerm{
+									TopologyKey:   "kubernetes.io/hostname",
+									LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+								},
+							}},
+						},
+					},
+					TopologySpreadConstraints: topologySpreadConstraints(disruption),
+					Containers: []corev1.Container{{
+						Name:            "lakom",
+						Image:           image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Args:            args,
+						Ports: []corev1.ContainerPort{
+							{Name: "https", ContainerPort: 10250, Protocol: corev1.ProtocolTCP},
+							{Name: "metrics", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+						},
+						LivenessProbe: &corev1.Probe{
+							ProbeHandler:        corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8081), Scheme: corev1.URISchemeHTTP}},
+							InitialDelaySeconds: 10,
+						},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler:        corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/readyz", Port: intstr.FromInt(8081), Scheme: corev1.URISchemeHTTP}},
+							InitialDelaySeconds: 5,
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("50m"),
+								corev1.ResourceMemory: resource.MustParse("64Mi"),
+							},
+						},
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "lakom-public-keys", MountPath: cosignPublicKeysVolumeMountPath, ReadOnly: true},
+							{Name: "lakom-server-tls", MountPath: "/etc/lakom/tls", ReadOnly: true},
+							{Name: "kubeconfig", MountPath: "/var/run/secrets/gardener.cloud/shoot/generic-kubeconfig", ReadOnly: true},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "lakom-public-keys", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: cosignPublicKeysSecretName}}},
+						{Name: "lakom-server-tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: serverTLSSecretName}}},
+						{
+							Name: "kubeconfig",
+							VolumeSource: corev1.VolumeSource{
+								Projected: &corev1.ProjectedVolumeSource{
+									DefaultMode: pointer.Int32(420),
+									Sources: []corev1.VolumeProjection{
+										{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: genericKubeconfigSecretName}, Items: []corev1.KeyToPath{{Key: "kubeconfig", Path: "kubeconfig"}}, Optional: pointer.Bool(false)}},
+										{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: shootAccessSecretName}, Items: []corev1.KeyToPath{{Key: "token", Path: "token"}}, Optional: pointer.Bool(false)}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func intOrStrPtr(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
+}
+
+// topologySpreadConstraints returns the TopologySpreadConstraints configured on the
+// DisruptionConfig, or nil if none are set, so callers can assign it directly to a PodSpec.
+func topologySpreadConstraints(disruption *lakomv1alpha1.DisruptionConfig) []corev1.TopologySpreadConstraint {
+	if disruption == nil || len(disruption.TopologySpreadConstraints) == 0 {
+		return nil
+	}
+	return disruption.TopologySpreadConstraints
+}
+
+func getVPA(namespace string) *vpaautoscalingv1.VerticalPodAutoscaler {
+	updateMode := vpaautoscalingv1.UpdateModeAuto
+
+	return &vpaautoscalingv1.VerticalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+			Labels:    getLabels(),
+		},
+		Spec: vpaautoscalingv1.VerticalPodAutoscalerSpec{
+			TargetRef: &autoscalingv1.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       serviceName,
+			},
+			UpdatePolicy: &vpaautoscalingv1.PodUpdatePolicy{
+				UpdateMode: &updateMode,
+			},
+			ResourcePolicy: &vpaautoscalingv1.PodResourcePolicy{
+				ContainerPolicies: []vpaautoscalingv1.ContainerResourcePolicy{{
+					ContainerName: "lakom",
+					MinAllowed: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("32Mi"),
+					},
+				}},
+			},
+		},
+	}
+}
@@ -5,18 +5,25 @@
 package lifecycle
 
 import (
+	"crypto/sha256"
 	b64 "encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/Masterminds/semver"
+	lakomconfigv1alpha1 "github.com/gardener/gardener-extension-shoot-lakom-service/pkg/apis/config/v1alpha1"
+	lakompolicyv1alpha1 "github.com/gardener/gardener-extension-shoot-lakom-service/pkg/apis/lakom/v1alpha1"
 	"github.com/gardener/gardener/pkg/resourcemanager/controller/garbagecollector/references"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
-	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
 )
 
 var _ = Describe("Actuator", func() {
@@ -49,69 +56,45 @@ var _ = Describe("Actuator", func() {
 	})
 
 	Context("getPDB", func() {
-		It("Should return PDB even when replicas is zero or unset", func() {
+		It("Should return a policy/v1 PDB defaulting to maxUnavailable: 1 even when replicas is zero or unset", func() {
 			var (
 				replicas  *int32
 				namespace = "default"
 			)
 
-			version, err := semver.NewVersion("v1.22.0")
-			Expect(err).ToNot(HaveOccurred())
-			Expect(version).ToNot(BeNil())
-
-			pdb, err := getPDB(replicas, namespace, version)
-			Expect(err).ToNot(HaveOccurred())
+			pdb := getPDB(replicas, namespace, nil)
 			Expect(pdb).ToNot(BeNil())
-
-			policyv1PDB, ok := pdb.(*policyv1.PodDisruptionBudget)
-			Expect(ok).To(BeTrue())
-			Expect(policyv1PDB.Spec.MaxUnavailable.IntValue()).To(Equal(1))
-
-			version, err = semver.NewVersion("v1.20.0")
-			Expect(err).ToNot(HaveOccurred())
-			Expect(version).ToNot(BeNil())
+			Expect(pdb.Spec.MaxUnavailable.IntValue()).To(Equal(1))
+			Expect(pdb.Spec.MinAvailable).To(BeNil())
 
 			replicas = pointer.Int32(0)
-			pdb, err = getPDB(replicas, namespace, version)
-			Expect(err).ToNot(HaveOccurred())
+			pdb = getPDB(replicas, namespace, nil)
 			Expect(pdb).ToNot(BeNil())
-
-			policyv1beta1PDB, ok := pdb.(*policyv1beta1.PodDisruptionBudget)
-			Expect(ok).To(BeTrue())
-			Expect(policyv1beta1PDB.Spec.MaxUnavailable.IntValue()).To(Equal(1))
+			Expect(pdb.Spec.MaxUnavailable.IntValue()).To(Equal(1))
 		})
 
-		DescribeTable("Should use the right apiVersion for PodDisruptionBudgets depending on k8s version",
-			func(k8sVersion string, expectedType interface{}) {
-				var (
-					replicas  = pointer.Int32(3)
-					namespace = "default"
-				)
+		It("Should never emit a policy/v1beta1 PodDisruptionBudget", func() {
+			pdb := getPDB(pointer.Int32(3), "default", &lakomconfigv1alpha1.DisruptionConfig{
+				MaxUnavailable: intOrStrPtr(intstr.FromInt(2)),
+			})
 
-				version, err := semver.NewVersion(k8sVersion)
-				Expect(err).ToNot(HaveOccurred())
+			Expect(pdb).To(BeAssignableToTypeOf(&policyv1.PodDisruptionBudget{}))
+		})
 
-				pdb, err := getPDB(replicas, namespace, version)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(pdb).ToNot(BeNil())
-				Expect(pdb).To(BeAssignableToTypeOf(expectedType))
+		DescribeTable("Should honor the DisruptionConfig's MinAvailable/MaxUnavailable, preferring MinAvailable",
+			func(disruption *lakomconfigv1alpha1.DisruptionConfig, expectedMinAvailable, expectedMaxUnavailable *intstr.IntOrString) {
+				pdb := getPDB(pointer.Int32(3), "default", disruption)
 
+				Expect(pdb.Spec.MinAvailable).To(Equal(expectedMinAvailable))
+				Expect(pdb.Spec.MaxUnavailable).To(Equal(expectedMaxUnavailable))
 			},
-			Entry("Should use policy/v1beta1 for 1.19.0", "1.19.0", &policyv1beta1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1beta1 for v1.19.0", "v1.19.0", &policyv1beta1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1beta1 for v1.20.0", "v1.20.0", &policyv1beta1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1beta1 for v1.20.1", "v1.20.1", &policyv1beta1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1beta1 for v1.20.0-gke.100", "v1.20.0-gke.100", &policyv1beta1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1beta1 for v1.20.0-0.0.0", "v1.20.0-0.0.0", &policyv1beta1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1beta1 for v1.20.1-0.0.0", "v1.20.1-0.0.0", &policyv1beta1.PodDisruptionBudget{}),
-
-			Entry("Should use policy/v1 for 1.21.0", "1.21.0", &policyv1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1 for v1.21.0", "v1.21.0", &policyv1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1 for v1.21.1", "v1.21.1", &policyv1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1 for v1.21.0-gke.100", "v1.21.0-gke.100", &policyv1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1 for v1.21.0-0.0.0", "v1.21.0-0.0.0", &policyv1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1 for v1.21.1-0.0.0", "v1.21.1-0.0.0", &policyv1.PodDisruptionBudget{}),
-			Entry("Should use policy/v1 for v1.22.0", "v1.22.0", &policyv1.PodDisruptionBudget{}),
+			Entry("No DisruptionConfig: defaults to maxUnavailable: 1", (*lakomconfigv1alpha1.DisruptionConfig)(nil), (*intstr.IntOrString)(nil), intOrStrPtr(intstr.FromInt(1))),
+			Entry("MinAvailable set", &lakomconfigv1alpha1.DisruptionConfig{MinAvailable: intOrStrPtr(intstr.FromInt(2))}, intOrStrPtr(intstr.FromInt(2)), (*intstr.IntOrString)(nil)),
+			Entry("MaxUnavailable set", &lakomconfigv1alpha1.DisruptionConfig{MaxUnavailable: intOrStrPtr(intstr.FromString("25%"))}, (*intstr.IntOrString)(nil), intOrStrPtr(intstr.FromString("25%"))),
+			Entry("Both set: MinAvailable takes precedence", &lakomconfigv1alpha1.DisruptionConfig{
+				MinAvailable:   intOrStrPtr(intstr.FromInt(2)),
+				MaxUnavailable: intOrStrPtr(intstr.FromInt(1)),
+			}, intOrStrPtr(intstr.FromInt(2)), (*intstr.IntOrString)(nil)),
 		)
 	})
 
@@ -131,7 +114,7 @@ var _ = Describe("Actuator", func() {
 
 		It("Should ensure the correct shoot resources are created", func() {
 
-			resources, err := getShootResources(caBundle, namespace, shootAccessServiceAccountName, failurePolicy)
+			resources, err := getShootResources(caBundle, namespace, shootAccessServiceAccountName, failurePolicy, nil, nil)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(resources).To(HaveLen(4))
 
@@ -145,7 +128,7 @@ var _ = Describe("Actuator", func() {
 
 		DescribeTable("Should ensure the mutating webhook config is correctly set",
 			func(ca []byte, ns string, fp admissionregistrationv1.FailurePolicyType) {
-				resources, err := getShootResources(ca, ns, shootAccessServiceAccountName, fp)
+				resources, err := getShootResources(ca, ns, shootAccessServiceAccountName, fp, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				mutatingWebhook, ok := resources[mutatingWebhookKey]
@@ -158,7 +141,7 @@ var _ = Describe("Actuator", func() {
 
 		DescribeTable("Should ensure the validating webhook config is correctly set",
 			func(ca []byte, ns string, fp admissionregistrationv1.FailurePolicyType) {
-				resources, err := getShootResources(ca, ns, shootAccessServiceAccountName, fp)
+				resources, err := getShootResources(ca, ns, shootAccessServiceAccountName, fp, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				validatingWebhook, ok := resources[validatingWebhookKey]
@@ -171,7 +154,7 @@ var _ = Describe("Actuator", func() {
 
 		DescribeTable("Should ensure the rolebinding is correctly set",
 			func(saName string) {
-				resources, err := getShootResources(caBundle, namespace, saName, failurePolicy)
+				resources, err := getShootResources(caBundle, namespace, saName, failurePolicy, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				roleBinding, ok := resources[roleBindingKey]
@@ -182,6 +165,122 @@ var _ = Describe("Actuator", func() {
 			Entry("ServiceAccount name: foo-bar", "foo-bar"),
 		)
 
+		DescribeTable("Should only add the attestation webhook when attestation policies are configured",
+			func(attestationVerification *lakomconfigv1alpha1.AttestationVerification, expectAttestationWebhook bool) {
+				resources, err := getShootResources(caBundle, namespace, shootAccessServiceAccountName, failurePolicy, attestationVerification, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				validatingWebhook, ok := resources[validatingWebhookKey]
+				Expect(ok).To(BeTrue())
+
+				if expectAttestationWebhook {
+					Expect(string(validatingWebhook)).To(Equal(expectedSeedValidatingWebhookWithAttestation(caBundle, namespace, failurePolicy)))
+				} else {
+					Expect(string(validatingWebhook)).To(Equal(expectedSeedValidatingWebhook(caBundle, namespace, failurePolicy)))
+				}
+			},
+			Entry("No attestation verification", nil, false),
+			Entry("Attestation verification without policies", &lakomconfigv1alpha1.AttestationVerification{}, false),
+			Entry("Attestation verification with policies", &attestationVerificationFixture, true),
+		)
+
+		It("Should extend the shoot Role with list/watch access to lakompolicies", func() {
+			resources, err := getShootResources(caBundle, namespace, shootAccessServiceAccountName, failurePolicy, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			role, ok := resources[roleKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(role)).To(Equal(expectedShootRole()))
+		})
+
+		DescribeTable("Should aggregate the webhook namespaceSelector from the configured LakomPolicies",
+			func(policies []lakompolicyv1alpha1.LakomPolicy, expectedNamespaces []string) {
+				resources, err := getShootResources(caBundle, namespace, shootAccessServiceAccountName, failurePolicy, nil, policies)
+				Expect(err).ToNot(HaveOccurred())
+
+				validatingWebhook, ok := resources[validatingWebhookKey]
+				Expect(ok).To(BeTrue())
+				for _, ns := range expectedNamespaces {
+					Expect(string(validatingWebhook)).To(ContainSubstring("- " + ns))
+				}
+			},
+			Entry("No policies: only kube-system", []lakompolicyv1alpha1.LakomPolicy(nil), []string{"kube-system"}),
+			Entry("Policy without NamespaceSelector: its own namespace is added", []lakompolicyv1alpha1.LakomPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+				Spec:       lakompolicyv1alpha1.LakomPolicySpec{ImageReferences: []string{"eu.gcr.io/my-project/*"}},
+			}}, []string{"kube-system", "default"}),
+			Entry("Policy with NamespaceSelector: the selected namespaces are added", []lakompolicyv1alpha1.LakomPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+				Spec: lakompolicyv1alpha1.LakomPolicySpec{
+					ImageReferences: []string{"eu.gcr.io/my-project/*"},
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{
+							Key:      "kubernetes.io/metadata.name",
+							Operator: metav1.LabelSelectorOpIn,
+							Values:   []string{"team-a", "team-b"},
+						}},
+					},
+				},
+			}}, []string{"kube-system", "team-a", "team-b"}),
+		)
+
+		DescribeTable("Should aggregate the webhook objectSelector from the configured LakomPolicies' PodSelectors",
+			func(policies []lakompolicyv1alpha1.LakomPolicy, expectedObjectSelector *metav1.LabelSelector) {
+				resources, err := getShootResources(caBundle, namespace, shootAccessServiceAccountName, failurePolicy, nil, policies)
+				Expect(err).ToNot(HaveOccurred())
+
+				validatingWebhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+				Expect(yaml.Unmarshal(resources[validatingWebhookKey], validatingWebhookConfig)).To(Succeed())
+
+				Expect(validatingWebhookConfig.Webhooks[0].ObjectSelector).To(Equal(expectedObjectSelector))
+			},
+			Entry("No policies: the default Gardener-managed-only selector is kept", []lakompolicyv1alpha1.LakomPolicy(nil), defaultObjectSelector()),
+			Entry("Policy without PodSelector: relaxed to match all pods, so non-Gardener-managed pods in the policy's namespace still reach the webhook",
+				[]lakompolicyv1alpha1.LakomPolicy{{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+					Spec:       lakompolicyv1alpha1.LakomPolicySpec{ImageReferences: []string{"eu.gcr.io/my-project/*"}},
+				}}, (*metav1.LabelSelector)(nil)),
+			Entry("Policies whose PodSelectors share a single MatchLabels key: unioned into one In-list",
+				[]lakompolicyv1alpha1.LakomPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "team-a-policy", Namespace: "team-a"},
+						Spec: lakompolicyv1alpha1.LakomPolicySpec{
+							ImageReferences: []string{"eu.gcr.io/my-project/*"},
+							PodSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "team-b-policy", Namespace: "team-b"},
+						Spec: lakompolicyv1alpha1.LakomPolicySpec{
+							ImageReferences: []string{"eu.gcr.io/my-project/*"},
+							PodSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+						},
+					},
+				}, &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      "team",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"a", "b"},
+					}},
+				}),
+			Entry("Policies whose PodSelectors use different keys: relaxed to match all pods",
+				[]lakompolicyv1alpha1.LakomPolicy{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "team-policy", Namespace: "team-a"},
+						Spec: lakompolicyv1alpha1.LakomPolicySpec{
+							ImageReferences: []string{"eu.gcr.io/my-project/*"},
+							PodSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "env-policy", Namespace: "team-b"},
+						Spec: lakompolicyv1alpha1.LakomPolicySpec{
+							ImageReferences: []string{"eu.gcr.io/my-project/*"},
+							PodSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+						},
+					},
+				}, (*metav1.LabelSelector)(nil)),
+		)
 	})
 
 	Context("getSeedResources", func() {
@@ -191,10 +290,14 @@ var _ = Describe("Actuator", func() {
 			shootAccessServiceAccountName = "extension-shoot-lakom-service"
 			serverTLSSecretName           = "shoot-lakom-service-tls" //#nosec G101 -- this is false positive
 			image                         = "eu.gcr.io/gardener-project/gardener/extensions/lakom:v0.0.0"
-			cosignSecretName              = "extension-shoot-lakom-service-cosign-public-keys-e3b0c442"
+			cosignSecretName              = "extension-shoot-lakom-service-cosign-public-keys-5cf336f7"
+			keylessOnlySecretName         = "extension-shoot-lakom-service-cosign-public-keys-103902ec"
+			keylessWithoutTLogSecretName  = "extension-shoot-lakom-service-cosign-public-keys-1e0f736e"
+			mixedSecretName               = "extension-shoot-lakom-service-cosign-public-keys-d7ea2449"
 
 			cosignSecretNameKey = "secret__" + namespace + "__" + cosignSecretName + ".yaml"
 			configMapKey        = "configmap__" + namespace + "__extension-shoot-lakom-service-monitoring.yaml"
+			serviceMonitorKey   = "servicemonitor__" + namespace + "__extension-shoot-lakom-service.yaml"
 			deploymentKey       = "deployment__" + namespace + "__extension-shoot-lakom-service.yaml"
 			pdbKey              = "poddisruptionbudget__" + namespace + "__extension-shoot-lakom-service.yaml"
 			serviceKey          = "service__" + namespace + "__extension-shoot-lakom-service.yaml"
@@ -203,9 +306,9 @@ var _ = Describe("Actuator", func() {
 		)
 
 		var (
-			replicas         int32
-			cosignPublicKeys []string
-			seedK8SVersion   *semver.Version
+			replicas            int32
+			cosignPublicKeys    []string
+			keylessVerification *lakomconfigv1alpha1.KeylessVerification
 		)
 
 		BeforeEach(func() {
@@ -223,9 +326,7 @@ hjZVcW2ygAvImCAULGph2fqGkNUszl7ycJH/Dntw4wMLSbstUZomqPuIVQ==
 `,
 			}
 
-			var err error
-			seedK8SVersion, err = semver.NewVersion("v1.24.0")
-			Expect(err).ToNot(HaveOccurred())
+			keylessVerification = nil
 		})
 
 		It("Should ensure the correct seed resources are created", func() {
@@ -236,8 +337,16 @@ hjZVcW2ygAvImCAULGph2fqGkNUszl7ycJH/Dntw4wMLSbstUZomqPuIVQ==
 				shootAccessServiceAccountName,
 				serverTLSSecretName,
 				cosignPublicKeys,
+				keylessVerification,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
 				image,
-				seedK8SVersion,
 			)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(resources).To(HaveLen(7))
@@ -260,9 +369,634 @@ hjZVcW2ygAvImCAULGph2fqGkNUszl7ycJH/Dntw4wMLSbstUZomqPuIVQ==
 				Expect(strResource).To(Equal(expectedResource), key)
 			}
 		})
+
+		DescribeTable("Should render the cosign material secret and deployment args for key-only, keyless-only and mixed policies",
+			func(keys []string, keyless *lakomconfigv1alpha1.KeylessVerification, expectedSecretName string, expectArgs []string) {
+				resources, err := getSeedResources(
+					&replicas,
+					namespace,
+					genericKubeconfigName,
+					shootAccessServiceAccountName,
+					serverTLSSecretName,
+					keys,
+					keyless,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					image,
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				secretKey := "secret__" + namespace + "__" + expectedSecretName + ".yaml"
+				secret, ok := resources[secretKey]
+				Expect(ok).To(BeTrue())
+
+				deployment, ok := resources[deploymentKey]
+				Expect(ok).To(BeTrue())
+
+				for _, arg := range expectArgs {
+					Expect(string(deployment)).To(ContainSubstring(arg))
+					_ = secret
+				}
+			},
+			Entry("Key-only policy", []string{"-----BEGIN PUBLIC KEY-----\nfoo\n-----END PUBLIC KEY-----\n"}, nil, "extension-shoot-lakom-service-cosign-public-keys-"+sha8("-----BEGIN PUBLIC KEY-----\nfoo\n-----END PUBLIC KEY-----\n"),
+				[]string{"--cosign-public-key-path=/etc/lakom/cosign/cosign.pub"}),
+			Entry("Keyless-only policy", []string(nil), &keylessVerificationFixture, keylessOnlySecretName,
+				[]string{"--keyless-policy-path=/etc/lakom/cosign/keyless-policy.yaml", "--rekor-url=https://rekor.sigstore.dev", "--insecure-ignore-tlog=false"}),
+			Entry("Keyless-only policy with RequireTLog disabled", []string(nil), &keylessVerificationWithoutTLogFixture, keylessWithoutTLogSecretName,
+				[]string{"--keyless-policy-path=/etc/lakom/cosign/keyless-policy.yaml", "--rekor-url=https://rekor.sigstore.dev", "--insecure-ignore-tlog=true"}),
+			Entry("Mixed key and keyless policy", []string{
+				`-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE5WIqxApep8Q53M5zrd0Hhuk03tCn
+On/cxJW6vXn3mvlqgyc4MO/ZXb5EputelfyP5n1NYWWcomeQTDG/E3EbdQ==
+-----END PUBLIC KEY-----
+`, `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEyLVOS/TWANf6sZJPDzogodvDz8NT
+hjZVcW2ygAvImCAULGph2fqGkNUszl7ycJH/Dntw4wMLSbstUZomqPuIVQ==
+-----END PUBLIC KEY-----
+`,
+			}, &keylessVerificationFixture, mixedSecretName,
+				[]string{"--cosign-public-key-path=/etc/lakom/cosign/cosign.pub", "--keyless-policy-path=/etc/lakom/cosign/keyless-policy.yaml"}),
+		)
+
+		It("Should render a custom Fulcio root certificate and Rekor public key into the secret and as deployment args", func() {
+			keylessVerification := &lakomconfigv1alpha1.KeylessVerification{
+				Identities:                keylessVerificationFixture.Identities,
+				FulcioRootCertificateData: []byte("-----BEGIN CERTIFICATE-----\ncustom-fulcio-root\n-----END CERTIFICATE-----\n"),
+				RekorPublicKeyData:        []byte("-----BEGIN PUBLIC KEY-----\ncustom-rekor-key\n-----END PUBLIC KEY-----\n"),
+			}
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				nil,
+				keylessVerification,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment, ok := resources[deploymentKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(deployment)).To(ContainSubstring("--fulcio-root-path=/etc/lakom/cosign/fulcio-root.pem"))
+			Expect(string(deployment)).To(ContainSubstring("--rekor-public-key-path=/etc/lakom/cosign/rekor.pub"))
+
+			secret := findCosignSecret(resources, namespace)
+			Expect(string(secret)).To(ContainSubstring("custom-fulcio-root"))
+			Expect(string(secret)).To(ContainSubstring("custom-rekor-key"))
+		})
+
+		It("Should render the attestation policy file and deployment arg when attestation policies are configured", func() {
+			expectedSecretName := "extension-shoot-lakom-service-cosign-public-keys-" + sha8(
+				attestationVerificationFixture.Policies[0].PredicateType+
+					attestationVerificationFixture.Policies[0].RequiredBuilderIDRegExp+
+					attestationVerificationFixture.Policies[0].SourceURIRegExp+
+					"3")
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				nil,
+				nil,
+				&attestationVerificationFixture,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			secretKey := "secret__" + namespace + "__" + expectedSecretName + ".yaml"
+			secret, ok := resources[secretKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(secret)).To(ContainSubstring("attestation-policy.yaml"))
+			Expect(string(secret)).To(ContainSubstring("predicateType: https://slsa.dev/provenance/v1"))
+			Expect(string(secret)).To(ContainSubstring("minSLSALevel: 3"))
+
+			deployment, ok := resources[deploymentKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(deployment)).To(ContainSubstring("--attestation-policy-path=/etc/lakom/cosign/attestation-policy.yaml"))
+		})
+
+		It("Should render the attestation signer and policy expression when configured", func() {
+			attestationVerification := &lakomconfigv1alpha1.AttestationVerification{
+				Policies: []lakomconfigv1alpha1.AttestationPolicy{{
+					PredicateType:            "https://cyclonedx.org/bom",
+					SignerPublicKeySecretRef: &corev1.LocalObjectReference{Name: "attestation-signer"},
+					PolicyExpression:         `!predicate.components.exists(c, c.name == "log4j-core")`,
+				}},
+			}
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				nil,
+				nil,
+				attestationVerification,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			secretKey := "secret__" + namespace + "__extension-shoot-lakom-service-cosign-public-keys-3294c634.yaml"
+			secret, ok := resources[secretKey]
+			Expect(ok).To(BeTrue())
+
+			decodedPolicy := decodeAttestationPolicyFile(secret)
+			Expect(decodedPolicy.Policies).To(HaveLen(1))
+			Expect(decodedPolicy.Policies[0].SignerPublicKeySecretRef).To(Equal("attestation-signer"))
+			Expect(decodedPolicy.Policies[0].PolicyExpression).To(Equal(`!predicate.components.exists(c, c.name == "log4j-core")`))
+		})
+
+		It("Should render the projected LakomPolicy set and deployment arg when policies are configured", func() {
+			policies := []lakompolicyv1alpha1.LakomPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "default"},
+				Spec: lakompolicyv1alpha1.LakomPolicySpec{
+					ImageReferences:        []string{"eu.gcr.io/my-project/*"},
+					RequiredPredicateTypes: []string{"https://slsa.dev/provenance/v1"},
+				},
+			}}
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				nil,
+				nil,
+				nil,
+				policies,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			secretName := "extension-shoot-lakom-service-cosign-public-keys-" + sha8("defaultmy-policyeu.gcr.io/my-project/*https://slsa.dev/provenance/v1")
+			secretKey := "secret__" + namespace + "__" + secretName + ".yaml"
+			secret, ok := resources[secretKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(secret)).To(ContainSubstring("lakom-policies.yaml"))
+			decodedPolicies := decodeLakomPoliciesFile(secret)
+			Expect(decodedPolicies.Policies).To(HaveLen(1))
+			Expect(decodedPolicies.Policies[0].Name).To(Equal("my-policy"))
+			Expect(decodedPolicies.Policies[0].Namespace).To(Equal("default"))
+
+			deployment, ok := resources[deploymentKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(deployment)).To(ContainSubstring("--lakom-policy-path=/etc/lakom/cosign/lakom-policies.yaml"))
+		})
+
+		It("Should render an Allow-action LakomPolicy as an exemption, without verification material", func() {
+			policies := []lakompolicyv1alpha1.LakomPolicy{{
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-system-exemption", Namespace: "kube-system"},
+				Spec: lakompolicyv1alpha1.LakomPolicySpec{
+					ImageReferences: []string{"registry.k8s.io/*"},
+					Action:          lakompolicyv1alpha1.LakomPolicyActionAllow,
+				},
+			}}
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				nil,
+				nil,
+				nil,
+				policies,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			secretName := "extension-shoot-lakom-service-cosign-public-keys-d014eb31"
+			secretKey := "secret__" + namespace + "__" + secretName + ".yaml"
+			secret, ok := resources[secretKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(secret)).To(ContainSubstring("action: Allow"))
+			Expect(string(secret)).ToNot(ContainSubstring("cosignPublicKeySecretRef"))
+		})
+
+		DescribeTable("Should render the monitoring resources according to the MonitoringConfig",
+			func(monitoring *lakomconfigv1alpha1.MonitoringConfig, expectConfigMap, expectServiceMonitor bool) {
+				resources, err := getSeedResources(
+					&replicas,
+					namespace,
+					genericKubeconfigName,
+					shootAccessServiceAccountName,
+					serverTLSSecretName,
+					cosignPublicKeys,
+					keylessVerification,
+					nil,
+					nil,
+					monitoring,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					image,
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, configMapOK := resources[configMapKey]
+				Expect(configMapOK).To(Equal(expectConfigMap))
+
+				serviceMonitor, serviceMonitorOK := resources[serviceMonitorKey]
+				Expect(serviceMonitorOK).To(Equal(expectServiceMonitor))
+				if expectServiceMonitor {
+					Expect(string(serviceMonitor)).To(Equal(expectedSeedServiceMonitor(namespace)))
+				}
+			},
+			Entry("Default (ConfigMap only)", nil, true, false),
+			Entry("ServiceMonitor alongside the ConfigMap", &lakomconfigv1alpha1.MonitoringConfig{ServiceMonitor: true}, true, true),
+			Entry("ServiceMonitor only", &lakomconfigv1alpha1.MonitoringConfig{ServiceMonitor: true, DisableScrapeConfigConfigMap: true}, false, true),
+		)
+
+		It("Should propagate the DisruptionConfig's MinAvailable and TopologySpreadConstraints into the PDB and Deployment", func() {
+			disruption := &lakomconfigv1alpha1.DisruptionConfig{
+				MinAvailable: intOrStrPtr(intstr.FromInt(2)),
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: getLabels()},
+				}},
+			}
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				cosignPublicKeys,
+				keylessVerification,
+				nil,
+				nil,
+				nil,
+				disruption,
+				nil,
+				nil,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			pdb, ok := resources[pdbKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(pdb)).To(Equal(expectedSeedPDBWithMinAvailable(namespace, 2)))
+
+			deployment, ok := resources[deploymentKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(deployment)).To(ContainSubstring("topologySpreadConstraints"))
+			Expect(string(deployment)).To(ContainSubstring("topologyKey: topology.kubernetes.io/zone"))
+		})
+
+		It("Should render the VerificationCache settings as deployment args", func() {
+			verificationCache := &lakomconfigv1alpha1.VerificationCache{
+				MaxEntries:  pointer.Int32(10000),
+				PositiveTTL: &metav1.Duration{Duration: 15 * time.Minute},
+				NegativeTTL: &metav1.Duration{Duration: 30 * time.Second},
+				RedisURL:    pointer.String("redis://lakom-cache.garden.svc:6379"),
+			}
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				cosignPublicKeys,
+				keylessVerification,
+				nil,
+				nil,
+				nil,
+				nil,
+				verificationCache,
+				nil,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment, ok := resources[deploymentKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(deployment)).To(ContainSubstring("--cache-ttl=15m0s"))
+			Expect(string(deployment)).To(ContainSubstring("--cache-max-entries=10000"))
+			Expect(string(deployment)).To(ContainSubstring("--cache-negative-ttl=30s"))
+			Expect(string(deployment)).To(ContainSubstring("--cache-redis-url=redis://lakom-cache.garden.svc:6379"))
+		})
+
+		It("Should render the RegistryConfig mirror, credentials and CA bundle settings into the registry config file and deployment arg", func() {
+			registryConfig := &lakomconfigv1alpha1.RegistryConfig{
+				Registries: []lakomconfigv1alpha1.RegistryOverride{
+					{
+						Host:                 "docker.io",
+						Mirror:               "internal-mirror.example.com/dockerhub",
+						CredentialsSecretRef: &corev1.LocalObjectReference{Name: "dockerhub-credentials"},
+					},
+					{
+						Host:               "airgapped-registry.example.com",
+						CABundleSecretRef:  &corev1.LocalObjectReference{Name: "airgapped-ca-bundle"},
+						InsecureSkipVerify: true,
+					},
+				},
+			}
+
+			resources, err := getSeedResources(
+				&replicas,
+				namespace,
+				genericKubeconfigName,
+				shootAccessServiceAccountName,
+				serverTLSSecretName,
+				cosignPublicKeys,
+				keylessVerification,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				registryConfig,
+				nil,
+				nil,
+				image,
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment, ok := resources[deploymentKey]
+			Expect(ok).To(BeTrue())
+			Expect(string(deployment)).To(ContainSubstring("--registry-config-path=/etc/lakom/cosign/registry-config.yaml"))
+
+			secret := findCosignSecret(resources, namespace)
+			Expect(string(secret)).To(ContainSubstring("registry-config.yaml"))
+			decodedConfig := decodeRegistryConfigFile(secret)
+			Expect(decodedConfig.Registries).To(HaveLen(2))
+			Expect(decodedConfig.Registries[0].Host).To(Equal("docker.io"))
+			Expect(decodedConfig.Registries[0].Mirror).To(Equal("internal-mirror.example.com/dockerhub"))
+			Expect(decodedConfig.Registries[0].CredentialsSecretRef).To(Equal("dockerhub-credentials"))
+			Expect(decodedConfig.Registries[1].Host).To(Equal("airgapped-registry.example.com"))
+			Expect(decodedConfig.Registries[1].CABundleSecretRef).To(Equal("airgapped-ca-bundle"))
+			Expect(decodedConfig.Registries[1].InsecureSkipVerify).To(BeTrue())
+		})
+
+		It("Should change the cosign material secret name when RegistryConfig changes, so the immutable Secret rotates", func() {
+			getSecretName := func(registryConfig *lakomconfigv1alpha1.RegistryConfig) string {
+				resources, err := getSeedResources(
+					&replicas,
+					namespace,
+					genericKubeconfigName,
+					shootAccessServiceAccountName,
+					serverTLSSecretName,
+					cosignPublicKeys,
+					keylessVerification,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					registryConfig,
+					nil,
+					nil,
+					image,
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				for key := range resources {
+					if strings.HasPrefix(key, "secret__"+namespace+"__extension-shoot-lakom-service-cosign-public-keys-") {
+						return key
+					}
+				}
+				Fail("no cosign material secret found among the resources")
+				return ""
+			}
+
+			nameWithoutRegistryConfig := getSecretName(nil)
+			nameWithMirror := getSecretName(&lakomconfigv1alpha1.RegistryConfig{
+				Registries: []lakomconfigv1alpha1.RegistryOverride{{Host: "docker.io", Mirror: "internal-mirror.example.com/dockerhub"}},
+			})
+			nameWithDifferentMirror := getSecretName(&lakomconfigv1alpha1.RegistryConfig{
+				Registries: []lakomconfigv1alpha1.RegistryOverride{{Host: "docker.io", Mirror: "other-mirror.example.com/dockerhub"}},
+			})
+
+			Expect(nameWithMirror).ToNot(Equal(nameWithoutRegistryConfig))
+			Expect(nameWithDifferentMirror).ToNot(Equal(nameWithMirror))
+		})
+
+		DescribeTable("Should render Mode and AuditSink as deployment args",
+			func(mode *lakomconfigv1alpha1.LakomMode, auditSink *lakomconfigv1alpha1.AuditSink, expectArgs []string) {
+				resources, err := getSeedResources(
+					&replicas,
+					namespace,
+					genericKubeconfigName,
+					shootAccessServiceAccountName,
+					serverTLSSecretName,
+					cosignPublicKeys,
+					keylessVerification,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+					mode,
+					auditSink,
+					image,
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				deployment, ok := resources[deploymentKey]
+				Expect(ok).To(BeTrue())
+
+				for _, arg := range expectArgs {
+					Expect(string(deployment)).To(ContainSubstring(arg))
+				}
+				if mode == nil {
+					Expect(string(deployment)).ToNot(ContainSubstring("--mode="))
+				}
+			},
+			Entry("Mode unset: no --mode arg", (*lakomconfigv1alpha1.LakomMode)(nil), (*lakomconfigv1alpha1.AuditSink)(nil), []string(nil)),
+			Entry("Mode Warn", modePtr(lakomconfigv1alpha1.LakomModeWarn), (*lakomconfigv1alpha1.AuditSink)(nil), []string{"--mode=Warn"}),
+			Entry("Mode Audit with all three sinks enabled", modePtr(lakomconfigv1alpha1.LakomModeAudit), &lakomconfigv1alpha1.AuditSink{
+				Events:     true,
+				WebhookURL: pointer.String("https://audit.example.com/lakom-violations"),
+				Log:        true,
+			}, []string{
+				"--mode=Audit",
+				"--audit-events=true",
+				"--audit-webhook-url=https://audit.example.com/lakom-violations",
+				"--audit-log=true",
+			}),
+		)
 	})
 })
 
+func modePtr(m lakomconfigv1alpha1.LakomMode) *lakomconfigv1alpha1.LakomMode {
+	return &m
+}
+
+var keylessVerificationFixture = lakomconfigv1alpha1.KeylessVerification{
+	Identities: []lakomconfigv1alpha1.Identity{{
+		IssuerRegExp:  "https://token.actions.githubusercontent.com",
+		SubjectRegExp: "https://github.com/example-org/example-repo/.*",
+	}},
+}
+
+var keylessVerificationWithoutTLogFixture = lakomconfigv1alpha1.KeylessVerification{
+	Identities: []lakomconfigv1alpha1.Identity{{
+		IssuerRegExp:  "https://token.actions.githubusercontent.com",
+		SubjectRegExp: "https://github.com/example-org/example-repo/.*",
+	}},
+	RequireTLog: pointer.Bool(false),
+}
+
+var attestationVerificationFixture = lakomconfigv1alpha1.AttestationVerification{
+	Policies: []lakomconfigv1alpha1.AttestationPolicy{{
+		PredicateType:           "https://slsa.dev/provenance/v1",
+		RequiredBuilderIDRegExp: "https://github.com/example-org/.*",
+		SourceURIRegExp:         "git\\+https://github.com/example-org/example-repo",
+		MinSLSALevel:            pointer.Int32(3),
+	}},
+}
+
+func sha8(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// findCosignSecret locates the cosign material Secret among the serialized resources by its
+// well-known name prefix, without the caller having to reconstruct the content hash suffix.
+func findCosignSecret(resources map[string][]byte, namespace string) []byte {
+	prefix := "secret__" + namespace + "__extension-shoot-lakom-service-cosign-public-keys-"
+	for key, resource := range resources {
+		if strings.HasPrefix(key, prefix) {
+			return resource
+		}
+	}
+	ExpectWithOffset(1, false).To(BeTrue(), "no cosign material secret found among the resources")
+	return nil
+}
+
+// decodedSecretStringData is the subset of corev1.Secret this test package decodes to reach a
+// rendered policy file's content.
+type decodedSecretStringData struct {
+	StringData map[string]string `json:"stringData"`
+}
+
+// decodedAttestationPolicyFile mirrors the schema renderAttestationPolicy emits, so tests can
+// assert on decoded values rather than on raw, hand-built YAML substrings.
+type decodedAttestationPolicyFile struct {
+	Policies []struct {
+		PredicateType            string `json:"predicateType"`
+		SignerPublicKeySecretRef string `json:"signerPublicKeySecretRef"`
+		PolicyExpression         string `json:"policyExpression"`
+	} `json:"policies"`
+}
+
+// decodeAttestationPolicyFile decodes a serialized cosign material Secret and re-parses its
+// attestation-policy.yaml stringData entry.
+func decodeAttestationPolicyFile(secret []byte) decodedAttestationPolicyFile {
+	var secretData decodedSecretStringData
+	ExpectWithOffset(1, yaml.Unmarshal(secret, &secretData)).To(Succeed())
+
+	var policyFile decodedAttestationPolicyFile
+	ExpectWithOffset(1, yaml.Unmarshal([]byte(secretData.StringData[attestationPolicyFileName]), &policyFile)).To(Succeed())
+
+	return policyFile
+}
+
+// decodedLakomPoliciesFile mirrors the schema renderLakomPolicies emits, so tests can assert on
+// decoded values rather than on raw, hand-built YAML substrings.
+type decodedLakomPoliciesFile struct {
+	Policies []struct {
+		Name            string   `json:"name"`
+		Namespace       string   `json:"namespace"`
+		Action          string   `json:"action"`
+		ImageReferences []string `json:"imageReferences"`
+	} `json:"policies"`
+}
+
+// decodeLakomPoliciesFile decodes a serialized cosign material Secret and re-parses its
+// lakom-policies.yaml stringData entry.
+func decodeLakomPoliciesFile(secret []byte) decodedLakomPoliciesFile {
+	var secretData decodedSecretStringData
+	ExpectWithOffset(1, yaml.Unmarshal(secret, &secretData)).To(Succeed())
+
+	var policyFile decodedLakomPoliciesFile
+	ExpectWithOffset(1, yaml.Unmarshal([]byte(secretData.StringData[lakomPolicyFileName]), &policyFile)).To(Succeed())
+
+	return policyFile
+}
+
+// decodedRegistryConfigFile mirrors the schema renderRegistryConfig emits, so tests can assert on
+// decoded values rather than on raw, hand-built YAML substrings.
+type decodedRegistryConfigFile struct {
+	Registries []struct {
+		Host                 string `json:"host"`
+		Mirror               string `json:"mirror"`
+		CredentialsSecretRef string `json:"credentialsSecretRef"`
+		CABundleSecretRef    string `json:"caBundleSecretRef"`
+		InsecureSkipVerify   bool   `json:"insecureSkipVerify"`
+	} `json:"registries"`
+}
+
+// decodeRegistryConfigFile decodes a serialized cosign material Secret and re-parses its
+// registry-config.yaml stringData entry.
+func decodeRegistryConfigFile(secret []byte) decodedRegistryConfigFile {
+	var secretData decodedSecretStringData
+	ExpectWithOffset(1, yaml.Unmarshal(secret, &secretData)).To(Succeed())
+
+	var configFile decodedRegistryConfigFile
+	ExpectWithOffset(1, yaml.Unmarshal([]byte(secretData.StringData[registryConfigFileName]), &configFile)).To(Succeed())
+
+	return configFile
+}
+
 func expectedShootMutatingWebhook(caBundle []byte, namespace string, failurePolicy admissionregistrationv1.FailurePolicyType) string {
 	var (
 		caBundleEncoded  = b64.StdEncoding.EncodeToString(caBundle)
@@ -366,6 +1100,90 @@ webhooks:
 `
 }
 
+func expectedSeedValidatingWebhookWithAttestation(caBundle []byte, namespace string, failurePolicy admissionregistrationv1.FailurePolicyType) string {
+	var (
+		caBundleEncoded  = b64.StdEncoding.EncodeToString(caBundle)
+		strFailurePolicy = string(failurePolicy)
+	)
+	return `apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  creationTimestamp: null
+  labels:
+    app.kubernetes.io/name: lakom
+    app.kubernetes.io/part-of: shoot-lakom-service
+    remediation.webhook.shoot.gardener.cloud/exclude: "true"
+  name: gardener-extension-shoot-lakom-service-shoot
+webhooks:
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    caBundle: ` + caBundleEncoded + `
+    url: https://extension-shoot-lakom-service.` + namespace + `/lakom/verify-cosign-signature
+  failurePolicy: ` + strFailurePolicy + `
+  matchPolicy: Equivalent
+  name: verify-signature.lakom.service.extensions.gardener.cloud
+  namespaceSelector:
+    matchExpressions:
+    - key: kubernetes.io/metadata.name
+      operator: In
+      values:
+      - kube-system
+  objectSelector:
+    matchExpressions:
+    - key: resources.gardener.cloud/managed-by
+      operator: In
+      values:
+      - gardener
+  rules:
+  - apiGroups:
+    - ""
+    apiVersions:
+    - v1
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - pods
+    - pods/ephemeralcontainers
+  sideEffects: None
+  timeoutSeconds: 25
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    caBundle: ` + caBundleEncoded + `
+    url: https://extension-shoot-lakom-service.` + namespace + `/lakom/verify-attestation
+  failurePolicy: ` + strFailurePolicy + `
+  matchPolicy: Equivalent
+  name: verify-attestation.lakom.service.extensions.gardener.cloud
+  namespaceSelector:
+    matchExpressions:
+    - key: kubernetes.io/metadata.name
+      operator: In
+      values:
+      - kube-system
+  objectSelector:
+    matchExpressions:
+    - key: resources.gardener.cloud/managed-by
+      operator: In
+      values:
+      - gardener
+  rules:
+  - apiGroups:
+    - ""
+    apiVersions:
+    - v1
+    operations:
+    - CREATE
+    - UPDATE
+    resources:
+    - pods
+    - pods/ephemeralcontainers
+  sideEffects: None
+  timeoutSeconds: 25
+`
+}
+
 func expectedShootRole() string {
 	return `apiVersion: rbac.authorization.k8s.io/v1
 kind: Role
@@ -383,6 +1201,13 @@ rules:
   - secrets
   verbs:
   - get
+- apiGroups:
+  - lakom.extensions.gardener.cloud
+  resources:
+  - lakompolicies
+  verbs:
+  - list
+  - watch
 `
 }
 
@@ -446,6 +1271,34 @@ metadata:
 `
 }
 
+func expectedSeedServiceMonitor(namespace string) string {
+	return `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  creationTimestamp: null
+  labels:
+    app.kubernetes.io/name: lakom
+    app.kubernetes.io/part-of: shoot-lakom-service
+    extensions.gardener.cloud/configuration: monitoring
+  name: extension-shoot-lakom-service
+  namespace: ` + namespace + `
+spec:
+  endpoints:
+  - metricRelabelings:
+    - action: keep
+      regex: ^lakom.*$
+      sourceLabels:
+      - __name__
+    port: metrics
+    scheme: http
+  namespaceSelector: {}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: lakom
+      app.kubernetes.io/part-of: shoot-lakom-service
+`
+}
+
 func expectedSeedDeployment(replicas int32, namespace, genericKubeconfigSecretName, shootAccessSecretName, image, cosignPublicKeysSecretName, serverTLSSecretName string) string {
 	var (
 		genericKubeconfigSecretNameAnnotationKey = references.AnnotationKey("secret", genericKubeconfigSecretName)
@@ -609,6 +1462,30 @@ status:
 `
 }
 
+func expectedSeedPDBWithMinAvailable(namespace string, minAvailable int) string {
+	return `apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  creationTimestamp: null
+  labels:
+    app.kubernetes.io/name: lakom
+    app.kubernetes.io/part-of: shoot-lakom-service
+  name: extension-shoot-lakom-service
+  namespace: ` + namespace + `
+spec:
+  minAvailable: ` + fmt.Sprintf("%d", minAvailable) + `
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: lakom
+      app.kubernetes.io/part-of: shoot-lakom-service
+status:
+  currentHealthy: 0
+  desiredHealthy: 0
+  disruptionsAllowed: 0
+  expectedPods: 0
+`
+}
+
 func expectedSeedSecretCosign(namespace, cosignSecretName string, cosignPublicKeys []string) string {
 	indentedKeys := []string{}
 	for _, key := range cosignPublicKeys {